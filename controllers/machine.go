@@ -21,8 +21,58 @@ import (
 	"github.com/beego/beego/utils/pagination"
 	"github.com/casvisor/casvisor/object"
 	"github.com/casvisor/casvisor/util"
+	"golang.org/x/net/websocket"
 )
 
+// requireMachinePermission enforces action ("read", "write", "power" or
+// "admin") for the signed-in Casdoor user against the machine identified
+// by id, responding with a 403 and returning false on denial.
+func (c *ApiController) requireMachinePermission(id, action string) bool {
+	user := c.GetSessionUsername()
+	if err := object.CheckMachinePermission(user, id, action); err != nil {
+		c.Ctx.Output.SetStatus(403)
+		c.ResponseError(err.Error())
+		return false
+	}
+
+	return true
+}
+
+// requireMachineOwnerPermission enforces action for the signed-in
+// Casdoor user against owner's whole machine namespace, for endpoints
+// that act on an owner rather than a single existing machine id.
+func (c *ApiController) requireMachineOwnerPermission(owner, action string) bool {
+	user := c.GetSessionUsername()
+	if err := object.CheckMachineOwnerPermission(user, owner, action); err != nil {
+		c.Ctx.Output.SetStatus(403)
+		c.ResponseError(err.Error())
+		return false
+	}
+
+	return true
+}
+
+// partitionMachinesByPermission splits machines into the ones the
+// signed-in user may act on with action and the ones they may not, the
+// latter reported as BulkFailedItem entries so bulk endpoints can fold
+// permission denials into the same partial-success response as any
+// other per-item failure.
+func (c *ApiController) partitionMachinesByPermission(machines []*object.Machine, action string) ([]*object.Machine, []object.BulkFailedItem) {
+	user := c.GetSessionUsername()
+
+	allowed := []*object.Machine{}
+	denied := []object.BulkFailedItem{}
+	for _, machine := range machines {
+		if err := object.CheckMachinePermission(user, machine.GetId(), action); err != nil {
+			denied = append(denied, object.BulkFailedItem{Id: machine.GetId(), Error: err.Error()})
+		} else {
+			allowed = append(allowed, machine)
+		}
+	}
+
+	return allowed, denied
+}
+
 // GetMachines
 // @Title GetMachines
 // @Tag Machine API
@@ -62,6 +112,8 @@ func (c *ApiController) GetMachines() {
 		}
 	}
 
+	user := c.GetSessionUsername()
+
 	if limit == "" || page == "" {
 		machines, err := object.GetMaskedMachines(object.GetMachines(owner))
 		if err != nil {
@@ -69,6 +121,12 @@ func (c *ApiController) GetMachines() {
 			return
 		}
 
+		machines, err = object.FilterMachinesByReadPermission(user, machines)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
 		c.ResponseOk(machines)
 	} else {
 		limit := util.ParseInt(limit)
@@ -85,6 +143,12 @@ func (c *ApiController) GetMachines() {
 			return
 		}
 
+		machines, err = object.FilterMachinesByReadPermission(user, machines)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
 		c.ResponseOk(machines, paginator.Nums())
 	}
 }
@@ -99,6 +163,10 @@ func (c *ApiController) GetMachines() {
 func (c *ApiController) GetMachine() {
 	id := c.Input().Get("id")
 
+	if !c.requireMachinePermission(id, "read") {
+		return
+	}
+
 	machine, err := object.GetMachine(id)
 	if err != nil {
 		c.ResponseError(err.Error())
@@ -158,6 +226,10 @@ func isDefaultMachine(machine *object.Machine) bool {
 func (c *ApiController) UpdateMachine() {
 	id := c.Input().Get("id")
 
+	if !c.requireMachinePermission(id, "write") {
+		return
+	}
+
 	var machine object.Machine
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &machine)
 	if err != nil {
@@ -169,6 +241,27 @@ func (c *ApiController) UpdateMachine() {
 	c.ServeJSON()
 }
 
+// PatchMachine
+// @Title PatchMachine
+// @Tag Machine API
+// @Description update machine via an RFC 7396 JSON Merge Patch: a field absent from the body is left untouched, an explicit null deletes/resets it
+// @Param   id     query    string  true        "The id ( owner/name ) of the machine"
+// @Param   body    body   object.Machine  true        "The fields of the machine to change"
+// @Success 200 {object} controllers.Response The Response object
+// @router /patch-machine [post]
+func (c *ApiController) PatchMachine() {
+	id := c.Input().Get("id")
+
+	if !c.requireMachinePermission(id, "write") {
+		return
+	}
+
+	canTouchSensitive := object.CheckMachinePermission(c.GetSessionUsername(), id, "admin") == nil
+
+	c.Data["json"] = wrapActionResponse(object.PatchMachine(id, c.Ctx.Input.RequestBody, canTouchSensitive))
+	c.ServeJSON()
+}
+
 // AddMachine
 // @Title AddMachine
 // @Tag Machine API
@@ -184,6 +277,10 @@ func (c *ApiController) AddMachine() {
 		return
 	}
 
+	if !c.requireMachinePermission(machine.GetId(), "write") {
+		return
+	}
+
 	c.Data["json"] = wrapActionResponse(object.AddMachine(&machine))
 	c.ServeJSON()
 }
@@ -203,6 +300,397 @@ func (c *ApiController) DeleteMachine() {
 		return
 	}
 
+	if !c.requireMachinePermission(machine.GetId(), "write") {
+		return
+	}
+
 	c.Data["json"] = wrapActionResponse(object.DeleteMachine(&machine))
 	c.ServeJSON()
 }
+
+// AddCloudPlatformRequest is the body accepted by AddCloudPlatform.
+type AddCloudPlatformRequest struct {
+	ProviderType string `json:"providerType"`
+	Addr         string `json:"addr"`
+	Region       string `json:"region"`
+	AccessKey    string `json:"accessKey"`
+	SecretKey    string `json:"secretKey"`
+}
+
+// AddCloudPlatform
+// @Title AddCloudPlatform
+// @Tag Machine API
+// @Description register a cloud platform and import its instances as machines
+// @Param   body    body   controllers.AddCloudPlatformRequest  true        "The credentials of the cloud platform"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-cloud-platform [post]
+func (c *ApiController) AddCloudPlatform() {
+	// The owner a cloud platform imports into is always the signed-in
+	// user's own namespace — it must never be taken from the request
+	// body, or any caller could import instances into an arbitrary
+	// owner's namespace.
+	owner, _ := util.GetOwnerAndNameFromId(c.GetSessionUsername())
+
+	if !c.requireMachineOwnerPermission(owner, "write") {
+		return
+	}
+
+	var req AddCloudPlatformRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &req)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	machines, err := object.AddCloudPlatform(owner, req.ProviderType, req.Addr, req.Region, req.AccessKey, req.SecretKey)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(machines)
+}
+
+// StartMachine
+// @Title StartMachine
+// @Tag Machine API
+// @Description power on a machine
+// @Param   id     query    string  true        "The id ( owner/name ) of the machine"
+// @Success 200 {object} object.Machine The Response object
+// @router /start-machine [post]
+func (c *ApiController) StartMachine() {
+	id := c.Input().Get("id")
+
+	if !c.requireMachinePermission(id, "power") {
+		return
+	}
+
+	machine, err := object.StartMachine(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(machine)
+}
+
+// StopMachine
+// @Title StopMachine
+// @Tag Machine API
+// @Description power off a machine
+// @Param   id     query    string  true        "The id ( owner/name ) of the machine"
+// @Success 200 {object} object.Machine The Response object
+// @router /stop-machine [post]
+func (c *ApiController) StopMachine() {
+	id := c.Input().Get("id")
+
+	if !c.requireMachinePermission(id, "power") {
+		return
+	}
+
+	machine, err := object.StopMachine(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(machine)
+}
+
+// RebootMachine
+// @Title RebootMachine
+// @Tag Machine API
+// @Description reboot a machine
+// @Param   id     query    string  true        "The id ( owner/name ) of the machine"
+// @Success 200 {object} object.Machine The Response object
+// @router /reboot-machine [post]
+func (c *ApiController) RebootMachine() {
+	id := c.Input().Get("id")
+
+	if !c.requireMachinePermission(id, "power") {
+		return
+	}
+
+	machine, err := object.RebootMachine(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(machine)
+}
+
+// ResizeMachineRequest is the body accepted by ResizeMachine.
+type ResizeMachineRequest struct {
+	Size string `json:"size"`
+}
+
+// ResizeMachine
+// @Title ResizeMachine
+// @Tag Machine API
+// @Description resize a machine to a new package/flavor
+// @Param   id     query    string  true        "The id ( owner/name ) of the machine"
+// @Param   body    body   controllers.ResizeMachineRequest  true        "The new size of the machine"
+// @Success 200 {object} object.Machine The Response object
+// @router /resize-machine [post]
+func (c *ApiController) ResizeMachine() {
+	id := c.Input().Get("id")
+
+	if !c.requireMachinePermission(id, "power") {
+		return
+	}
+
+	var req ResizeMachineRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &req)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	machine, err := object.ResizeMachine(id, req.Size)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(machine)
+}
+
+// GetMachinesByTag
+// @Title GetMachinesByTag
+// @Tag Machine API
+// @Description get machines owned by owner that carry the given tag key/value pair
+// @Param   owner     query    string  true        "The owner of the machines"
+// @Param   key     query    string  true        "The tag key"
+// @Param   value     query    string  true        "The tag value"
+// @Success 200 {object} object.Machine The Response object
+// @router /get-machines-by-tag [get]
+func (c *ApiController) GetMachinesByTag() {
+	owner := c.Input().Get("owner")
+	key := c.Input().Get("key")
+	value := c.Input().Get("value")
+
+	machines, err := object.GetMaskedMachines(object.GetMachinesByTag(owner, key, value))
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	machines, err = object.FilterMachinesByReadPermission(c.GetSessionUsername(), machines)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(machines)
+}
+
+// UpdateMachineTagsRequest is the body accepted by UpdateMachineTags.
+type UpdateMachineTagsRequest struct {
+	Tags map[string]string `json:"tags"`
+}
+
+// UpdateMachineTags
+// @Title UpdateMachineTags
+// @Tag Machine API
+// @Description update the tags of a machine
+// @Param   id     query    string  true        "The id ( owner/name ) of the machine"
+// @Param   body    body   controllers.UpdateMachineTagsRequest  true        "The new tags of the machine"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-machine-tags [post]
+func (c *ApiController) UpdateMachineTags() {
+	id := c.Input().Get("id")
+
+	if !c.requireMachinePermission(id, "write") {
+		return
+	}
+
+	var req UpdateMachineTagsRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &req)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateMachineTags(id, req.Tags))
+	c.ServeJSON()
+}
+
+// UpdateMachineFirewallRequest is the body accepted by UpdateMachineFirewall.
+type UpdateMachineFirewallRequest struct {
+	FirewallEnabled bool                  `json:"firewallEnabled"`
+	FirewallRules   []object.FirewallRule `json:"firewallRules"`
+}
+
+// UpdateMachineFirewall
+// @Title UpdateMachineFirewall
+// @Tag Machine API
+// @Description update the firewall configuration of a machine
+// @Param   id     query    string  true        "The id ( owner/name ) of the machine"
+// @Param   body    body   controllers.UpdateMachineFirewallRequest  true        "The new firewall configuration of the machine"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-machine-firewall [post]
+func (c *ApiController) UpdateMachineFirewall() {
+	id := c.Input().Get("id")
+
+	if !c.requireMachinePermission(id, "write") {
+		return
+	}
+
+	var req UpdateMachineFirewallRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &req)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateMachineFirewall(id, req.FirewallEnabled, req.FirewallRules))
+	c.ServeJSON()
+}
+
+// StreamMachines
+// @Title StreamMachines
+// @Tag Machine API
+// @Description upgrade to a WebSocket and push machine state/metric events for owner as they happen
+// @Param   owner     query    string  true        "The owner of the machines"
+// @router /stream-machines [get]
+func (c *ApiController) StreamMachines() {
+	owner := c.Input().Get("owner")
+
+	if !c.requireMachineOwnerPermission(owner, "read") {
+		return
+	}
+
+	user := c.GetSessionUsername()
+
+	handler := websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		events := object.Subscribe(owner)
+		defer object.Unsubscribe(owner, events)
+
+		stopMetrics := make(chan struct{})
+		defer close(stopMetrics)
+		go object.PollMachineMetrics(owner, stopMetrics)
+
+		for event := range events {
+			// Subscribing only proves the user may read *something* in
+			// owner's namespace; re-check each event's specific machine so
+			// a user entitled to a subset of owner's machines doesn't see
+			// live state/metric events for the rest over the socket.
+			if object.CheckMachinePermission(user, event.Id, "read") != nil {
+				continue
+			}
+
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		}
+	})
+	handler.ServeHTTP(c.Ctx.ResponseWriter, c.Ctx.Request)
+}
+
+func (c *ApiController) getBulkMachines() ([]*object.Machine, error) {
+	var machines []*object.Machine
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &machines)
+	return machines, err
+}
+
+// AddMachines
+// @Title AddMachines
+// @Tag Machine API
+// @Description add multiple machines, reporting per-item success/failure
+// @Param   atomic     query    string  false        "Whether to roll back every row if any one fails"
+// @Param   body    body   []object.Machine  true        "The machines to add"
+// @Success 200 {object} object.BulkResult The Response object
+// @router /add-machines [post]
+func (c *ApiController) AddMachines() {
+	machines, err := c.getBulkMachines()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	allowed, denied := c.partitionMachinesByPermission(machines, "write")
+	atomic := c.Input().Get("atomic") == "true"
+
+	if atomic && len(denied) > 0 {
+		c.ResponseOk(&object.BulkResult{Failed: denied})
+		return
+	}
+
+	result, err := object.AddMachines(allowed, atomic)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	result.Failed = append(result.Failed, denied...)
+
+	c.ResponseOk(result)
+}
+
+// UpdateMachines
+// @Title UpdateMachines
+// @Tag Machine API
+// @Description update multiple machines, reporting per-item success/failure
+// @Param   atomic     query    string  false        "Whether to roll back every row if any one fails"
+// @Param   body    body   []object.Machine  true        "The machines to update"
+// @Success 200 {object} object.BulkResult The Response object
+// @router /update-machines [post]
+func (c *ApiController) UpdateMachines() {
+	machines, err := c.getBulkMachines()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	allowed, denied := c.partitionMachinesByPermission(machines, "write")
+	atomic := c.Input().Get("atomic") == "true"
+
+	if atomic && len(denied) > 0 {
+		c.ResponseOk(&object.BulkResult{Failed: denied})
+		return
+	}
+
+	result, err := object.UpdateMachines(allowed, atomic)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	result.Failed = append(result.Failed, denied...)
+
+	c.ResponseOk(result)
+}
+
+// DeleteMachines
+// @Title DeleteMachines
+// @Tag Machine API
+// @Description delete multiple machines, reporting per-item success/failure
+// @Param   atomic     query    string  false        "Whether to roll back every row if any one fails"
+// @Param   body    body   []object.Machine  true        "The machines to delete"
+// @Success 200 {object} object.BulkResult The Response object
+// @router /delete-machines [post]
+func (c *ApiController) DeleteMachines() {
+	machines, err := c.getBulkMachines()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	allowed, denied := c.partitionMachinesByPermission(machines, "write")
+	atomic := c.Input().Get("atomic") == "true"
+
+	if atomic && len(denied) > 0 {
+		c.ResponseOk(&object.BulkResult{Failed: denied})
+		return
+	}
+
+	result, err := object.DeleteMachines(allowed, atomic)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	result.Failed = append(result.Failed, denied...)
+
+	c.ResponseOk(result)
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"xorm.io/xorm"
+)
+
+type Adapter struct {
+	engine *xorm.Engine
+}
+
+var adapter *Adapter
+
+// GetSession builds a query session pre-filtered by owner, field/value and
+// ordering, shared by every object's list/count query. A "tag:" prefixed
+// field is not a real column, so callers that accept one must filter the
+// result in memory themselves (see machine_tag.go) instead of relying on
+// this session to do it at the DB layer.
+func GetSession(owner string, offset, limit int, field, value, sortField, sortOrder string) *xorm.Session {
+	session := adapter.engine.NewSession()
+
+	if offset != -1 && limit != -1 {
+		session.Limit(limit, offset)
+	}
+
+	if owner != "" {
+		session = session.And("owner=?", owner)
+	}
+
+	if field != "" && value != "" && !isTagField(field) {
+		session = session.And(field+" like ?", "%"+value+"%")
+	}
+
+	if sortField == "" {
+		sortField = "created_time"
+	}
+
+	if sortOrder == "ascend" {
+		session = session.Asc(sortField)
+	} else {
+		session = session.Desc(sortField)
+	}
+
+	return session
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CloudProvider is implemented by every pluggable cloud backend (AWS,
+// Aliyun, Azure, GCP, Joyent-style SmartMachine, ...). Plugins register
+// themselves with RegisterCloudProvider under the provider type they
+// handle so SyncMachinesCloud can fan out to them.
+type CloudProvider interface {
+	// Init configures the plugin with the address, region and
+	// credentials of a cloud account.
+	Init(addr, region, accessKey, secretKey string) error
+	// ListInstances returns the cloud's view of the owner's instances,
+	// already converted into Machine rows.
+	ListInstances(owner string) ([]*Machine, error)
+	CreateInstance(machine *Machine) error
+	DeleteInstance(machine *Machine) error
+	PowerAction(id, action string) error
+	// ApplyFirewall pushes machine's FirewallEnabled/FirewallRules to the
+	// underlying cloud instance.
+	ApplyFirewall(machine *Machine) error
+	// GetMetrics returns a live CPU/memory/network sample for the
+	// instance identified by id, for the /stream-machines metrics feed.
+	GetMetrics(id string) (*MachineMetrics, error)
+}
+
+// CloudManager is a registry of CloudProvider plugins keyed by provider
+// type (e.g. "aws", "aliyun", "azure", "gcp", "smartmachine").
+type CloudManager struct {
+	lock      sync.RWMutex
+	providers map[string]CloudProvider
+}
+
+var cloudManager = &CloudManager{providers: map[string]CloudProvider{}}
+
+// RegisterCloudProvider makes a CloudProvider plugin available under
+// providerType. It is typically called from an init() function in the
+// file implementing that plugin.
+func RegisterCloudProvider(providerType string, provider CloudProvider) {
+	cloudManager.lock.Lock()
+	defer cloudManager.lock.Unlock()
+
+	cloudManager.providers[providerType] = provider
+}
+
+func (cm *CloudManager) getProvider(providerType string) (CloudProvider, bool) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	provider, ok := cm.providers[providerType]
+	return provider, ok
+}
+
+// GetCloudProvider returns the plugin registered for providerType, or an
+// error if no such plugin was registered.
+func GetCloudProvider(providerType string) (CloudProvider, error) {
+	provider, ok := cloudManager.getProvider(providerType)
+	if !ok {
+		return nil, fmt.Errorf("the cloud provider type: %s is not supported", providerType)
+	}
+
+	return provider, nil
+}
+
+// AddCloudPlatform configures the plugin registered for providerType with
+// the given credentials, lists its instances and imports them as Machine
+// rows owned by owner.
+func AddCloudPlatform(owner, providerType, addr, region, accessKey, secretKey string) ([]*Machine, error) {
+	provider, err := GetCloudProvider(providerType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := provider.Init(addr, region, accessKey, secretKey); err != nil {
+		return nil, err
+	}
+
+	instances, err := provider.ListInstances(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	importedMachines := []*Machine{}
+	for _, instance := range instances {
+		instance.Owner = owner
+		instance.ProviderType = providerType
+		instance.AccessKey = accessKey
+		instance.SecretKey = secretKey
+
+		existing, err := getMachine(instance.Owner, instance.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing == nil {
+			if _, err := AddMachine(instance); err != nil {
+				return nil, err
+			}
+		} else {
+			if _, err := UpdateMachine(instance.GetId(), instance); err != nil {
+				return nil, err
+			}
+		}
+
+		importedMachines = append(importedMachines, instance)
+	}
+
+	return importedMachines, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "fmt"
+
+// baseCloudProvider holds the credentials common to every plugin below.
+// Real plugins embed it and talk to the provider's SDK; these are left
+// as thin stubs until that wiring lands.
+type baseCloudProvider struct {
+	providerType string
+	addr         string
+	region       string
+	accessKey    string
+	secretKey    string
+}
+
+func (p *baseCloudProvider) Init(addr, region, accessKey, secretKey string) error {
+	p.addr = addr
+	p.region = region
+	p.accessKey = accessKey
+	p.secretKey = secretKey
+	return nil
+}
+
+func (p *baseCloudProvider) ListInstances(owner string) ([]*Machine, error) {
+	return nil, fmt.Errorf("ListInstances is not yet implemented for provider type: %s", p.providerType)
+}
+
+func (p *baseCloudProvider) CreateInstance(machine *Machine) error {
+	return fmt.Errorf("CreateInstance is not yet implemented for provider type: %s", p.providerType)
+}
+
+func (p *baseCloudProvider) DeleteInstance(machine *Machine) error {
+	return fmt.Errorf("DeleteInstance is not yet implemented for provider type: %s", p.providerType)
+}
+
+func (p *baseCloudProvider) PowerAction(id, action string) error {
+	return fmt.Errorf("PowerAction is not yet implemented for provider type: %s", p.providerType)
+}
+
+func (p *baseCloudProvider) ApplyFirewall(machine *Machine) error {
+	return fmt.Errorf("ApplyFirewall is not yet implemented for provider type: %s", p.providerType)
+}
+
+func (p *baseCloudProvider) GetMetrics(id string) (*MachineMetrics, error) {
+	return nil, fmt.Errorf("GetMetrics is not yet implemented for provider type: %s", p.providerType)
+}
+
+type AwsCloudProvider struct{ baseCloudProvider }
+
+type AliyunCloudProvider struct{ baseCloudProvider }
+
+type AzureCloudProvider struct{ baseCloudProvider }
+
+type GcpCloudProvider struct{ baseCloudProvider }
+
+// SmartMachineCloudProvider targets Joyent-style SmartOS/Triton clouds.
+type SmartMachineCloudProvider struct{ baseCloudProvider }
+
+func init() {
+	RegisterCloudProvider("aws", &AwsCloudProvider{baseCloudProvider{providerType: "aws"}})
+	RegisterCloudProvider("aliyun", &AliyunCloudProvider{baseCloudProvider{providerType: "aliyun"}})
+	RegisterCloudProvider("azure", &AzureCloudProvider{baseCloudProvider{providerType: "azure"}})
+	RegisterCloudProvider("gcp", &GcpCloudProvider{baseCloudProvider{providerType: "gcp"}})
+	RegisterCloudProvider("smartmachine", &SmartMachineCloudProvider{baseCloudProvider{providerType: "smartmachine"}})
+}
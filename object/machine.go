@@ -0,0 +1,304 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casvisor/casvisor/util"
+	"xorm.io/core"
+)
+
+type Machine struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	Provider     string `xorm:"varchar(100)" json:"provider"`
+	ProviderType string `xorm:"varchar(100)" json:"providerType"`
+	Region       string `xorm:"varchar(100)" json:"region"`
+	Zone         string `xorm:"varchar(100)" json:"zone"`
+	Category     string `xorm:"varchar(100)" json:"category"`
+	Type         string `xorm:"varchar(100)" json:"type"`
+	Size         string `xorm:"varchar(100)" json:"size"`
+
+	Tag        string `xorm:"varchar(100)" json:"tag"`
+	State      string `xorm:"varchar(100)" json:"state"`
+	ExpireTime string `xorm:"varchar(100)" json:"expireTime"`
+
+	Image     string `xorm:"varchar(100)" json:"image"`
+	Os        string `xorm:"varchar(100)" json:"os"`
+	PublicIp  string `xorm:"varchar(100)" json:"publicIp"`
+	PrivateIp string `xorm:"varchar(100)" json:"privateIp"`
+
+	Tags            map[string]string `xorm:"varchar(1000)" json:"tags"`
+	Metadata        map[string]string `xorm:"varchar(1000)" json:"metadata"`
+	Networks        []string          `xorm:"varchar(1000)" json:"networks"`
+	FirewallEnabled bool              `json:"firewallEnabled"`
+	FirewallRules   []FirewallRule    `xorm:"varchar(2000)" json:"firewallRules"`
+
+	AccessKey string `xorm:"varchar(200)" json:"accessKey"`
+	SecretKey string `xorm:"varchar(200)" json:"secretKey"`
+}
+
+// FirewallRule is a single inbound/outbound rule applied to a Machine
+// when FirewallEnabled is true.
+type FirewallRule struct {
+	Direction string `json:"direction"`
+	Protocol  string `json:"protocol"`
+	PortRange string `json:"portRange"`
+	Source    string `json:"source"`
+	Action    string `json:"action"`
+}
+
+func (machine *Machine) GetId() string {
+	return fmt.Sprintf("%s/%s", machine.Owner, machine.Name)
+}
+
+// IsDefault returns true if the machine is a placeholder row that was
+// never synced from a real cloud provider.
+func (machine *Machine) IsDefault() bool {
+	if machine.PublicIp != "" || machine.PrivateIp != "" {
+		return false
+	}
+
+	return machine.Provider == "provider_1" &&
+		machine.State == "Active" &&
+		machine.Tag == "" &&
+		machine.ExpireTime == ""
+}
+
+func GetMachineCount(owner, field, value string) (int64, error) {
+	if isTagField(field) {
+		machines, err := GetMachinesByTag(owner, tagKeyFromField(field), value)
+		if err != nil {
+			return 0, err
+		}
+		return int64(len(machines)), nil
+	}
+
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&Machine{})
+}
+
+func GetMachines(owner string) ([]*Machine, error) {
+	machines := []*Machine{}
+	err := adapter.engine.Desc("created_time").Find(&machines, &Machine{Owner: owner})
+	if err != nil {
+		return nil, err
+	}
+
+	return machines, nil
+}
+
+func GetPaginationMachines(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*Machine, error) {
+	if isTagField(field) {
+		return getPaginationMachinesByTag(owner, offset, limit, tagKeyFromField(field), value, sortField, sortOrder)
+	}
+
+	machines := []*Machine{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&machines)
+	if err != nil {
+		return nil, err
+	}
+
+	return machines, nil
+}
+
+// getPaginationMachinesByTag filters by tag key/value through
+// machineHasTag (the same function GetMachinesByTag uses) so the two
+// never disagree on what matches, then applies sort/offset/limit
+// in memory since "tags" isn't a queryable column.
+func getPaginationMachinesByTag(owner string, offset, limit int, tagKey, tagValue, sortField, sortOrder string) ([]*Machine, error) {
+	machines := []*Machine{}
+	session := GetSession(owner, -1, -1, "", "", sortField, sortOrder)
+	if err := session.Find(&machines); err != nil {
+		return nil, err
+	}
+
+	matched := []*Machine{}
+	for _, machine := range machines {
+		if machineHasTag(machine, tagKey, tagValue) {
+			matched = append(matched, machine)
+		}
+	}
+
+	if offset < 0 || offset >= len(matched) {
+		return []*Machine{}, nil
+	}
+
+	end := offset + limit
+	if limit < 0 || end > len(matched) {
+		end = len(matched)
+	}
+
+	return matched[offset:end], nil
+}
+
+func getMachine(owner, name string) (*Machine, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	machine := Machine{Owner: owner, Name: name}
+	existed, err := adapter.engine.Get(&machine)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &machine, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetMachine(id string) (*Machine, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getMachine(owner, name)
+}
+
+func GetMaskedMachine(machine *Machine, errs ...error) (*Machine, error) {
+	if len(errs) > 0 && errs[0] != nil {
+		return nil, errs[0]
+	}
+
+	if machine == nil {
+		return nil, nil
+	}
+
+	machine.AccessKey = ""
+	machine.SecretKey = ""
+	return machine, nil
+}
+
+func GetMaskedMachines(machines []*Machine, errs ...error) ([]*Machine, error) {
+	if len(errs) > 0 && errs[0] != nil {
+		return nil, errs[0]
+	}
+
+	for _, machine := range machines {
+		_, err := GetMaskedMachine(machine)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return machines, nil
+}
+
+func UpdateMachine(id string, machine *Machine) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if m, err := getMachine(owner, name); err != nil {
+		return false, err
+	} else if m == nil {
+		return false, nil
+	}
+
+	// The owner/name in id are the ones the caller's permission was
+	// actually checked against; force them onto machine so a caller can't
+	// smuggle a different "owner"/"name" through the request body and have
+	// the row re-keyed, or have the resulting event published into another
+	// tenant's /stream-machines channel.
+	machine.Owner = owner
+	machine.Name = name
+
+	affected, err := adapter.engine.ID(core.PK{owner, name}).AllCols().Update(machine)
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 {
+		publishMachineStateEvent(machine)
+	}
+
+	return affected != 0, nil
+}
+
+func AddMachine(machine *Machine) (bool, error) {
+	affected, err := adapter.engine.Insert(machine)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteMachine(machine *Machine) (bool, error) {
+	affected, err := adapter.engine.ID(core.PK{machine.Owner, machine.Name}).Delete(&Machine{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// SyncMachinesCloud refreshes the owner's machines by fanning out to the
+// registered CloudProvider plugin of every provider type used by the
+// owner's non-default machines and importing the instances it reports.
+// A provider type whose ListInstances call fails (e.g. because it is not
+// yet implemented, or its credentials are currently bad) is skipped rather
+// than aborting the sync, so one bad provider type can't turn GetMachines/
+// GetMachine into a hard failure for every other machine the owner has.
+func SyncMachinesCloud(owner string) (bool, error) {
+	machines, err := GetMachines(owner)
+	if err != nil {
+		return false, err
+	}
+
+	providerTypes := map[string]bool{}
+	for _, machine := range machines {
+		if machine.IsDefault() {
+			continue
+		}
+		providerTypes[machine.ProviderType] = true
+	}
+
+	synced := true
+	for providerType := range providerTypes {
+		provider, ok := cloudManager.getProvider(providerType)
+		if !ok {
+			continue
+		}
+
+		instances, err := provider.ListInstances(owner)
+		if err != nil {
+			synced = false
+			continue
+		}
+
+		for _, instance := range instances {
+			existing, err := getMachine(instance.Owner, instance.Name)
+			if err != nil {
+				return false, err
+			}
+
+			if existing == nil {
+				if _, err := AddMachine(instance); err != nil {
+					return false, err
+				}
+				publishMachineStateEvent(instance)
+			} else {
+				if _, err := UpdateMachine(instance.GetId(), instance); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+
+	return synced, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "github.com/casvisor/casvisor/util"
+
+// MachineAuditRecord traces a lifecycle transition (start/stop/reboot/
+// resize/...) performed against a machine, so operators can see who did
+// what and when.
+type MachineAuditRecord struct {
+	Id          int64  `xorm:"pk autoincr" json:"id"`
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	Name        string `xorm:"varchar(100) index" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Action   string `xorm:"varchar(100)" json:"action"`
+	OldState string `xorm:"varchar(100)" json:"oldState"`
+	NewState string `xorm:"varchar(100)" json:"newState"`
+	IsError  bool   `json:"isError"`
+	ErrorMsg string `xorm:"varchar(500)" json:"errorMsg"`
+}
+
+func addMachineAuditRecord(machine *Machine, action, oldState, newState string, actionErr error) error {
+	record := &MachineAuditRecord{
+		Owner:       machine.Owner,
+		Name:        machine.Name,
+		CreatedTime: util.GetCurrentTime(),
+		Action:      action,
+		OldState:    oldState,
+		NewState:    newState,
+	}
+
+	if actionErr != nil {
+		record.IsError = true
+		record.ErrorMsg = actionErr.Error()
+	}
+
+	_, err := adapter.engine.Insert(record)
+	return err
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "sync"
+
+// defaultBulkWorkerCount is how many items a bulk machine operation
+// processes concurrently. It is a fixed constant: neither the bulk
+// functions nor the bulk endpoints accept a different worker count.
+const defaultBulkWorkerCount = 8
+
+// BulkResult reports the outcome of a bulk machine operation item by
+// item, so a caller importing dozens of rows at once sees exactly which
+// ones failed instead of a single first-error-wins response.
+type BulkResult struct {
+	Succeeded []string         `json:"succeeded"`
+	Failed    []BulkFailedItem `json:"failed"`
+	// UndoFailed lists items whose compensating rollback itself failed
+	// during an atomic run: the original operation on these ids is still
+	// in effect (they also appear in Succeeded) even though the overall
+	// call reported failure, and the caller must reconcile them by hand.
+	UndoFailed []BulkFailedItem `json:"undoFailed,omitempty"`
+}
+
+type BulkFailedItem struct {
+	Id    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// runBulk applies op to every machine in machines using a bounded worker
+// pool, collecting a BulkResult. If atomic is true and any item fails,
+// every successfully-applied item is rolled back via undo before
+// returning.
+func runBulk(machines []*Machine, atomic bool, op func(*Machine) error, undo func(*Machine) error) (*BulkResult, error) {
+	type outcome struct {
+		machine *Machine
+		err     error
+	}
+
+	jobs := make(chan *Machine)
+	results := make(chan outcome, len(machines))
+
+	var wg sync.WaitGroup
+	for i := 0; i < defaultBulkWorkerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for machine := range jobs {
+				results <- outcome{machine: machine, err: op(machine)}
+			}
+		}()
+	}
+
+	go func() {
+		for _, machine := range machines {
+			jobs <- machine
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	result := &BulkResult{}
+	succeededMachines := []*Machine{}
+	for o := range results {
+		if o.err != nil {
+			result.Failed = append(result.Failed, BulkFailedItem{Id: o.machine.GetId(), Error: o.err.Error()})
+		} else {
+			result.Succeeded = append(result.Succeeded, o.machine.GetId())
+			succeededMachines = append(succeededMachines, o.machine)
+		}
+	}
+
+	if atomic && len(result.Failed) > 0 {
+		stillApplied := []string{}
+		for _, machine := range succeededMachines {
+			if err := undo(machine); err != nil {
+				result.UndoFailed = append(result.UndoFailed, BulkFailedItem{Id: machine.GetId(), Error: err.Error()})
+				stillApplied = append(stillApplied, machine.GetId())
+			}
+		}
+
+		result.Succeeded = stillApplied
+		return result, nil
+	}
+
+	return result, nil
+}
+
+func AddMachines(machines []*Machine, atomic bool) (*BulkResult, error) {
+	return runBulk(machines, atomic, func(machine *Machine) error {
+		_, err := AddMachine(machine)
+		return err
+	}, func(machine *Machine) error {
+		_, err := DeleteMachine(machine)
+		return err
+	})
+}
+
+func UpdateMachines(machines []*Machine, atomic bool) (*BulkResult, error) {
+	priorMachines := map[string]*Machine{}
+	for _, machine := range machines {
+		prior, err := getMachine(machine.Owner, machine.Name)
+		if err != nil {
+			return nil, err
+		}
+		priorMachines[machine.GetId()] = prior
+	}
+
+	return runBulk(machines, atomic, func(machine *Machine) error {
+		_, err := UpdateMachine(machine.GetId(), machine)
+		return err
+	}, func(machine *Machine) error {
+		prior := priorMachines[machine.GetId()]
+		if prior == nil {
+			return nil
+		}
+		_, err := UpdateMachine(prior.GetId(), prior)
+		return err
+	})
+}
+
+func DeleteMachines(machines []*Machine, atomic bool) (*BulkResult, error) {
+	// Capture the full row before deleting it: a real caller's delete
+	// payload is typically ID-only, so re-inserting the request-body
+	// struct on rollback would resurrect a near-empty row instead of
+	// restoring what was actually deleted.
+	priorMachines := map[string]*Machine{}
+	for _, machine := range machines {
+		prior, err := getMachine(machine.Owner, machine.Name)
+		if err != nil {
+			return nil, err
+		}
+		priorMachines[machine.GetId()] = prior
+	}
+
+	return runBulk(machines, atomic, func(machine *Machine) error {
+		_, err := DeleteMachine(machine)
+		return err
+	}, func(machine *Machine) error {
+		prior := priorMachines[machine.GetId()]
+		if prior == nil {
+			return nil
+		}
+		_, err := AddMachine(prior)
+		return err
+	})
+}
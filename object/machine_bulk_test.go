@@ -0,0 +1,112 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func sortedStrings(s []string) []string {
+	res := append([]string{}, s...)
+	sort.Strings(res)
+	return res
+}
+
+func TestRunBulkNonAtomicReportsPerItemFailures(t *testing.T) {
+	machines := []*Machine{
+		{Owner: "o", Name: "m1"},
+		{Owner: "o", Name: "m2"},
+		{Owner: "o", Name: "m3"},
+	}
+
+	result, err := runBulk(machines, false, func(m *Machine) error {
+		if m.Name == "m2" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}, func(m *Machine) error {
+		t.Fatalf("undo should not be called when atomic is false")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := sortedStrings(result.Succeeded); fmt.Sprint(got) != fmt.Sprint([]string{"o/m1", "o/m3"}) {
+		t.Fatalf("unexpected succeeded: %v", got)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Id != "o/m2" {
+		t.Fatalf("unexpected failed: %v", result.Failed)
+	}
+}
+
+func TestRunBulkAtomicRollsBackOnPartialFailure(t *testing.T) {
+	machines := []*Machine{
+		{Owner: "o", Name: "m1"},
+		{Owner: "o", Name: "m2"},
+	}
+
+	undone := map[string]bool{}
+	result, err := runBulk(machines, true, func(m *Machine) error {
+		if m.Name == "m2" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}, func(m *Machine) error {
+		undone[m.GetId()] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Succeeded) != 0 {
+		t.Fatalf("expected no succeeded items after rollback, got: %v", result.Succeeded)
+	}
+	if len(result.UndoFailed) != 0 {
+		t.Fatalf("expected no undo failures, got: %v", result.UndoFailed)
+	}
+	if !undone["o/m1"] {
+		t.Fatalf("expected the successful item to be rolled back")
+	}
+}
+
+func TestRunBulkAtomicSurfacesUndoFailure(t *testing.T) {
+	machines := []*Machine{
+		{Owner: "o", Name: "m1"},
+		{Owner: "o", Name: "m2"},
+	}
+
+	result, err := runBulk(machines, true, func(m *Machine) error {
+		if m.Name == "m2" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}, func(m *Machine) error {
+		return fmt.Errorf("undo failed")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.UndoFailed) != 1 || result.UndoFailed[0].Id != "o/m1" {
+		t.Fatalf("expected undo failure to be reported for o/m1, got: %v", result.UndoFailed)
+	}
+	if len(result.Succeeded) != 1 || result.Succeeded[0] != "o/m1" {
+		t.Fatalf("expected o/m1 to still be reported as applied since its rollback failed, got: %v", result.Succeeded)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "sync"
+
+// Event is pushed to every subscriber of an owner's machines whenever a
+// machine's state changes or a new metrics sample ticks in.
+type Event struct {
+	Type    string      `json:"type"` // "state" or "metric"
+	Id      string      `json:"id"`
+	Payload interface{} `json:"payload"`
+}
+
+// machineHub is a simple owner-scoped pub/sub used to drive the
+// /stream-machines WebSocket without polling the REST API.
+type machineHub struct {
+	lock        sync.Mutex
+	subscribers map[string][]chan Event
+}
+
+var defaultMachineHub = &machineHub{subscribers: map[string][]chan Event{}}
+
+// Subscribe registers a new listener for owner's machine events. The
+// channel is buffered so a slow reader cannot block publishers; it must
+// be drained by the caller until the caller is done listening.
+func Subscribe(owner string) <-chan Event {
+	ch := make(chan Event, 16)
+
+	defaultMachineHub.lock.Lock()
+	defer defaultMachineHub.lock.Unlock()
+	defaultMachineHub.subscribers[owner] = append(defaultMachineHub.subscribers[owner], ch)
+
+	return ch
+}
+
+// Unsubscribe removes a channel previously returned by Subscribe.
+func Unsubscribe(owner string, ch <-chan Event) {
+	defaultMachineHub.lock.Lock()
+	defer defaultMachineHub.lock.Unlock()
+
+	subs := defaultMachineHub.subscribers[owner]
+	for i, sub := range subs {
+		if sub == ch {
+			defaultMachineHub.subscribers[owner] = append(subs[:i], subs[i+1:]...)
+			close(sub)
+			break
+		}
+	}
+}
+
+// Publish fans event out to every subscriber of owner. Full subscriber
+// channels drop the event rather than blocking the publisher.
+func Publish(owner string, event Event) {
+	defaultMachineHub.lock.Lock()
+	defer defaultMachineHub.lock.Unlock()
+
+	for _, ch := range defaultMachineHub.subscribers[owner] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// publishMachineStateEvent publishes a masked copy of machine so
+// AccessKey/SecretKey never leave the server over /stream-machines,
+// matching the masking GetMaskedMachine already does for REST reads.
+func publishMachineStateEvent(machine *Machine) {
+	masked := *machine
+	masked.AccessKey = ""
+	masked.SecretKey = ""
+	Publish(machine.Owner, Event{Type: "state", Id: machine.GetId(), Payload: &masked})
+}
+
+func publishMachineMetricEvent(machine *Machine, metric *MachineMetrics) {
+	Publish(machine.Owner, Event{Type: "metric", Id: machine.GetId(), Payload: metric})
+}
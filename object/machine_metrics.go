@@ -0,0 +1,73 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "time"
+
+// MachineMetrics is a single CPU/memory/network sample reported by a
+// machine's cloud provider.
+type MachineMetrics struct {
+	CpuPercent    float64 `json:"cpuPercent"`
+	MemoryPercent float64 `json:"memoryPercent"`
+	NetworkInBps  int64   `json:"networkInBps"`
+	NetworkOutBps int64   `json:"networkOutBps"`
+}
+
+// machineMetricsPollInterval is how often PollMachineMetrics asks each
+// provider for a fresh sample.
+const machineMetricsPollInterval = 5 * time.Second
+
+// PollMachineMetrics periodically fetches a metrics sample for every
+// non-default machine owned by owner from its CloudProvider plugin and
+// publishes it to the machine hub, until stop is closed. It is meant to
+// run for the lifetime of a single /stream-machines connection.
+func PollMachineMetrics(owner string, stop <-chan struct{}) {
+	ticker := time.NewTicker(machineMetricsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			pollMachineMetricsOnce(owner)
+		}
+	}
+}
+
+func pollMachineMetricsOnce(owner string) {
+	machines, err := GetMachines(owner)
+	if err != nil {
+		return
+	}
+
+	for _, machine := range machines {
+		if machine.IsDefault() {
+			continue
+		}
+
+		provider, ok := cloudManager.getProvider(machine.ProviderType)
+		if !ok {
+			continue
+		}
+
+		metrics, err := provider.GetMetrics(machine.GetId())
+		if err != nil {
+			continue
+		}
+
+		publishMachineMetricEvent(machine, metrics)
+	}
+}
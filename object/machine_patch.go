@@ -0,0 +1,123 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// sensitiveMachineFields gates fields that a JSON merge patch cannot
+// touch without an extra permission check, since a careless or malicious
+// patch could otherwise use an absent-vs-null distinction to slip in new
+// credentials, swap the provider, or (via owner/name) move the row to a
+// different tenant's namespace entirely.
+var sensitiveMachineFields = map[string]bool{
+	"accessKey":    true,
+	"secretKey":    true,
+	"provider":     true,
+	"providerType": true,
+	"owner":        true,
+	"name":         true,
+}
+
+// mergePatchJSON applies an RFC 7396 JSON Merge Patch: keys absent from
+// patch are left untouched, keys explicitly set to null are deleted from
+// target, and any other key overwrites the corresponding value in target.
+func mergePatchJSON(target, patch map[string]interface{}) (map[string]interface{}, []string, error) {
+	touchedSensitive := []string{}
+
+	for key, patchValue := range patch {
+		if sensitiveMachineFields[key] {
+			touchedSensitive = append(touchedSensitive, key)
+		}
+
+		if patchValue == nil {
+			delete(target, key)
+			continue
+		}
+
+		if patchObj, isPatchObj := patchValue.(map[string]interface{}); isPatchObj {
+			targetObj, _ := target[key].(map[string]interface{})
+			if targetObj == nil {
+				targetObj = map[string]interface{}{}
+			}
+
+			merged, sensitive, err := mergePatchJSON(targetObj, patchObj)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			target[key] = merged
+			touchedSensitive = append(touchedSensitive, sensitive...)
+			continue
+		}
+
+		target[key] = patchValue
+	}
+
+	return target, touchedSensitive, nil
+}
+
+// PatchMachine applies patchBody as an RFC 7396 JSON Merge Patch to the
+// machine identified by id: a key absent from the patch leaves the
+// current DB value untouched, and an explicit null deletes/resets it.
+// canTouchSensitive gates the credential/provider fields so a merge
+// patch cannot be used to silently escalate privileges.
+func PatchMachine(id string, patchBody []byte, canTouchSensitive bool) (bool, error) {
+	machine, err := GetMachine(id)
+	if err != nil {
+		return false, err
+	}
+	if machine == nil {
+		return false, fmt.Errorf("the machine: %s does not exist", id)
+	}
+
+	currentBytes, err := json.Marshal(machine)
+	if err != nil {
+		return false, err
+	}
+
+	var current map[string]interface{}
+	if err = json.Unmarshal(currentBytes, &current); err != nil {
+		return false, err
+	}
+
+	var patch map[string]interface{}
+	if err = json.Unmarshal(patchBody, &patch); err != nil {
+		return false, err
+	}
+
+	merged, touchedSensitive, err := mergePatchJSON(current, patch)
+	if err != nil {
+		return false, err
+	}
+
+	if len(touchedSensitive) > 0 && !canTouchSensitive {
+		return false, fmt.Errorf("the patch is not allowed to modify sensitive field(s): %v", touchedSensitive)
+	}
+
+	mergedBytes, err := json.Marshal(merged)
+	if err != nil {
+		return false, err
+	}
+
+	var patchedMachine Machine
+	if err = json.Unmarshal(mergedBytes, &patchedMachine); err != nil {
+		return false, err
+	}
+
+	return UpdateMachine(id, &patchedMachine)
+}
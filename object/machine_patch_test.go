@@ -0,0 +1,138 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "testing"
+
+func TestMergePatchJSONLeavesAbsentKeysUntouched(t *testing.T) {
+	target := map[string]interface{}{"name": "m1", "region": "us-east"}
+	patch := map[string]interface{}{"region": "us-west"}
+
+	merged, sensitive, err := mergePatchJSON(target, patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged["name"] != "m1" {
+		t.Fatalf("expected untouched key to survive, got: %v", merged["name"])
+	}
+	if merged["region"] != "us-west" {
+		t.Fatalf("expected patched key to be overwritten, got: %v", merged["region"])
+	}
+	if len(sensitive) != 0 {
+		t.Fatalf("expected no sensitive fields touched, got: %v", sensitive)
+	}
+}
+
+func TestMergePatchJSONExplicitNullDeletesKey(t *testing.T) {
+	target := map[string]interface{}{"name": "m1", "image": "ubuntu-22.04"}
+	patch := map[string]interface{}{"image": nil}
+
+	merged, _, err := mergePatchJSON(target, patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := merged["image"]; ok {
+		t.Fatalf("expected image to be deleted, got: %v", merged["image"])
+	}
+	if merged["name"] != "m1" {
+		t.Fatalf("expected unrelated key to survive, got: %v", merged["name"])
+	}
+}
+
+func TestMergePatchJSONMergesNestedObjects(t *testing.T) {
+	target := map[string]interface{}{
+		"metadata": map[string]interface{}{"env": "prod", "team": "infra"},
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{"team": "platform", "owner": nil},
+	}
+
+	merged, _, err := mergePatchJSON(target, patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	metadata, ok := merged["metadata"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected metadata to still be a nested object, got: %T", merged["metadata"])
+	}
+	if metadata["env"] != "prod" {
+		t.Fatalf("expected untouched nested key to survive, got: %v", metadata["env"])
+	}
+	if metadata["team"] != "platform" {
+		t.Fatalf("expected nested key to be overwritten, got: %v", metadata["team"])
+	}
+	if _, ok := metadata["owner"]; ok {
+		t.Fatalf("expected nested null to delete the key, got: %v", metadata["owner"])
+	}
+}
+
+func TestMergePatchJSONReportsSensitiveFieldsIncludingNested(t *testing.T) {
+	target := map[string]interface{}{
+		"accessKey": "AK123",
+		"nested":    map[string]interface{}{"secretKey": "old"},
+	}
+	patch := map[string]interface{}{
+		"accessKey": "AK456",
+		"nested":    map[string]interface{}{"secretKey": "new"},
+	}
+
+	_, sensitive, err := mergePatchJSON(target, patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, key := range sensitive {
+		found[key] = true
+	}
+	if !found["accessKey"] {
+		t.Fatalf("expected accessKey to be reported as sensitive, got: %v", sensitive)
+	}
+	if !found["secretKey"] {
+		t.Fatalf("expected nested secretKey to be reported as sensitive, got: %v", sensitive)
+	}
+}
+
+func TestMergePatchJSONReportsOwnerAndNameAsSensitive(t *testing.T) {
+	target := map[string]interface{}{"owner": "tenant-a", "name": "m1"}
+	patch := map[string]interface{}{"owner": "tenant-b"}
+
+	_, sensitive, err := mergePatchJSON(target, patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, key := range sensitive {
+		found[key] = true
+	}
+	if !found["owner"] {
+		t.Fatalf("expected owner to be reported as sensitive, got: %v", sensitive)
+	}
+}
+
+func TestMergePatchJSONNonSensitivePatchReportsNothing(t *testing.T) {
+	target := map[string]interface{}{"displayName": "old"}
+	patch := map[string]interface{}{"displayName": "new"}
+
+	_, sensitive, err := mergePatchJSON(target, patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sensitive) != 0 {
+		t.Fatalf("expected no sensitive fields, got: %v", sensitive)
+	}
+}
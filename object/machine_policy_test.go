@@ -0,0 +1,117 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeMachinePolicy lets tests drive CheckMachinePermission and
+// FilterMachinesByReadPermission without a real Casdoor instance: allowed
+// is keyed by "user/id" and decides the verdict, errId (if non-empty)
+// forces Enforce itself to fail for that id.
+type fakeMachinePolicy struct {
+	allowed map[string]bool
+	errId   string
+}
+
+func (p *fakeMachinePolicy) check(user, id string) (bool, error) {
+	if p.errId != "" && id == p.errId {
+		return false, fmt.Errorf("casdoor unreachable")
+	}
+	return p.allowed[user+"/"+id], nil
+}
+
+func (p *fakeMachinePolicy) CanRead(user, id string) (bool, error)  { return p.check(user, id) }
+func (p *fakeMachinePolicy) CanWrite(user, id string) (bool, error) { return p.check(user, id) }
+func (p *fakeMachinePolicy) CanPower(user, id string) (bool, error) { return p.check(user, id) }
+func (p *fakeMachinePolicy) CanAdmin(user, id string) (bool, error) { return p.check(user, id) }
+
+func withFakeMachinePolicy(t *testing.T, fake *fakeMachinePolicy) {
+	original := machinePolicy
+	machinePolicy = fake
+	t.Cleanup(func() { machinePolicy = original })
+}
+
+func TestCheckMachinePermission(t *testing.T) {
+	withFakeMachinePolicy(t, &fakeMachinePolicy{allowed: map[string]bool{
+		"alice/o/m1": true,
+	}})
+
+	tests := []struct {
+		name    string
+		user    string
+		id      string
+		action  string
+		wantErr bool
+	}{
+		{name: "allowed read", user: "alice", id: "o/m1", action: "read", wantErr: false},
+		{name: "denied read for a different user", user: "bob", id: "o/m1", action: "read", wantErr: true},
+		{name: "allowed action does not imply a different action", user: "alice", id: "o/m1", action: "write", wantErr: true},
+		{name: "unknown action is rejected", user: "alice", id: "o/m1", action: "delete", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckMachinePermission(test.user, test.id, test.action)
+			if test.wantErr && err == nil {
+				t.Fatalf("expected an error, got nil")
+			}
+			if !test.wantErr && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckMachinePermissionPropagatesEnforceError(t *testing.T) {
+	withFakeMachinePolicy(t, &fakeMachinePolicy{errId: "o/m1"})
+
+	err := CheckMachinePermission("alice", "o/m1", "read")
+	if err == nil {
+		t.Fatalf("expected the underlying enforce error to propagate")
+	}
+	if _, ok := err.(*ErrMachinePermissionDenied); ok {
+		t.Fatalf("expected a raw enforce error, not ErrMachinePermissionDenied, got: %v", err)
+	}
+}
+
+func TestFilterMachinesByReadPermission(t *testing.T) {
+	withFakeMachinePolicy(t, &fakeMachinePolicy{allowed: map[string]bool{
+		"alice/o/m1": true,
+		"alice/o/m3": true,
+	}})
+
+	machines := []*Machine{
+		{Owner: "o", Name: "m1"},
+		{Owner: "o", Name: "m2"},
+		{Owner: "o", Name: "m3"},
+	}
+
+	filtered, err := FilterMachinesByReadPermission("alice", machines)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(filtered) != 2 {
+		t.Fatalf("expected 2 machines to survive the filter, got: %d", len(filtered))
+	}
+	for _, machine := range filtered {
+		if machine.Name == "m2" {
+			t.Fatalf("expected m2 to be filtered out, got: %v", filtered)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "fmt"
+
+const (
+	MachineStateActive    = "Active"
+	MachineStateStopped   = "Stopped"
+	MachineStateRebooting = "Rebooting"
+	MachineStateResizing  = "Resizing"
+)
+
+// powerActionGuardErr reports whether action should be rejected outright,
+// without ever contacting the cloud provider, because the machine is
+// already in guardState (e.g. starting an already-Active machine, or
+// stopping an already-Stopped one, is a no-op the caller should have
+// known better than to ask for). A blank guardState means the action has
+// no idempotency guard (reboot/resize can run from any state).
+func powerActionGuardErr(id, currentState, guardState string) error {
+	if guardState != "" && currentState == guardState {
+		return fmt.Errorf("the machine: %s is already %s", id, guardState)
+	}
+	return nil
+}
+
+// nextPowerState picks the state to persist after provider.PowerAction
+// returns: resultState on success, or a rollback to oldState on failure,
+// so a failed provider call never leaves the machine stuck in a pending
+// state like Rebooting/Resizing.
+func nextPowerState(oldState, resultState string, actionErr error) string {
+	if actionErr == nil {
+		return resultState
+	}
+	return oldState
+}
+
+// doPowerAction runs a single power-lifecycle transition on machine: it
+// enforces the idempotency guard, dispatches to the machine's
+// CloudProvider plugin, persists the resulting state and leaves an audit
+// trail regardless of outcome.
+func doPowerAction(id, action string, guardState, pendingState, resultState string, newSize string) (*Machine, error) {
+	machine, err := GetMachine(id)
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil {
+		return nil, fmt.Errorf("the machine: %s does not exist", id)
+	}
+
+	if err = powerActionGuardErr(id, machine.State, guardState); err != nil {
+		return nil, err
+	}
+
+	oldState := machine.State
+
+	provider, err := GetCloudProvider(machine.ProviderType)
+	if err != nil {
+		_ = addMachineAuditRecord(machine, action, oldState, oldState, err)
+		return nil, err
+	}
+
+	machine.State = pendingState
+	if newSize != "" {
+		machine.Size = newSize
+	}
+
+	actionErr := provider.PowerAction(id, action)
+	machine.State = nextPowerState(oldState, resultState, actionErr)
+
+	if _, err = UpdateMachine(id, machine); err != nil {
+		return nil, err
+	}
+
+	if err = addMachineAuditRecord(machine, action, oldState, machine.State, actionErr); err != nil {
+		return nil, err
+	}
+
+	if actionErr != nil {
+		return nil, actionErr
+	}
+
+	return machine, nil
+}
+
+func StartMachine(id string) (*Machine, error) {
+	return doPowerAction(id, "start", MachineStateActive, MachineStateActive, MachineStateActive, "")
+}
+
+func StopMachine(id string) (*Machine, error) {
+	return doPowerAction(id, "stop", MachineStateStopped, MachineStateStopped, MachineStateStopped, "")
+}
+
+func RebootMachine(id string) (*Machine, error) {
+	return doPowerAction(id, "reboot", "", MachineStateRebooting, MachineStateActive, "")
+}
+
+func ResizeMachine(id, newSize string) (*Machine, error) {
+	return doPowerAction(id, "resize", "", MachineStateResizing, MachineStateActive, newSize)
+}
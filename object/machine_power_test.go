@@ -0,0 +1,69 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPowerActionGuardErr(t *testing.T) {
+	tests := []struct {
+		name         string
+		currentState string
+		guardState   string
+		wantErr      bool
+	}{
+		{name: "start an already-active machine is rejected", currentState: MachineStateActive, guardState: MachineStateActive, wantErr: true},
+		{name: "start a stopped machine is allowed", currentState: MachineStateStopped, guardState: MachineStateActive, wantErr: false},
+		{name: "stop an already-stopped machine is rejected", currentState: MachineStateStopped, guardState: MachineStateStopped, wantErr: true},
+		{name: "stop an active machine is allowed", currentState: MachineStateActive, guardState: MachineStateStopped, wantErr: false},
+		{name: "no guard state means any current state is allowed", currentState: MachineStateRebooting, guardState: "", wantErr: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := powerActionGuardErr("o/m1", test.currentState, test.guardState)
+			if test.wantErr && err == nil {
+				t.Fatalf("expected an idempotency guard error, got nil")
+			}
+			if !test.wantErr && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestNextPowerState(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldState    string
+		resultState string
+		actionErr   error
+		want        string
+	}{
+		{name: "successful action moves to resultState", oldState: MachineStateActive, resultState: MachineStateStopped, actionErr: nil, want: MachineStateStopped},
+		{name: "failed action rolls back to oldState", oldState: MachineStateActive, resultState: MachineStateRebooting, actionErr: fmt.Errorf("provider unreachable"), want: MachineStateActive},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := nextPowerState(test.oldState, test.resultState, test.actionErr)
+			if got != test.want {
+				t.Fatalf("expected state %q, got %q", test.want, got)
+			}
+		})
+	}
+}
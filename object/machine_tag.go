@@ -0,0 +1,107 @@
+// Copyright 2024 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isTagField reports whether field is the "tag:<key>" pseudo-field used
+// by GetPaginationMachines/GetMachineCount to filter on a tag key/value
+// pair, as opposed to a real Machine column.
+func isTagField(field string) bool {
+	return strings.HasPrefix(field, "tag:")
+}
+
+// tagKeyFromField extracts the tag key out of a "tag:<key>" pseudo-field.
+func tagKeyFromField(field string) string {
+	return strings.TrimPrefix(field, "tag:")
+}
+
+// machineHasTag is the single place that decides whether a machine
+// matches a tag key/value pair, so GetMachinesByTag and the "tag:"
+// pseudo-field filter in GetPaginationMachines/GetMachineCount can never
+// disagree on the same query.
+func machineHasTag(machine *Machine, tagKey, tagValue string) bool {
+	value, ok := machine.Tags[tagKey]
+	return ok && value == tagValue
+}
+
+// GetMachinesByTag returns every machine owned by owner whose Tags map
+// contains tagKey with exactly tagValue.
+func GetMachinesByTag(owner, tagKey, tagValue string) ([]*Machine, error) {
+	machines, err := GetMachines(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*Machine{}
+	for _, machine := range machines {
+		if machineHasTag(machine, tagKey, tagValue) {
+			res = append(res, machine)
+		}
+	}
+
+	return res, nil
+}
+
+// UpdateMachineTags replaces the Tags map of the machine identified by id.
+func UpdateMachineTags(id string, tags map[string]string) (bool, error) {
+	machine, err := GetMachine(id)
+	if err != nil {
+		return false, err
+	}
+	if machine == nil {
+		return false, fmt.Errorf("the machine: %s does not exist", id)
+	}
+
+	machine.Tags = tags
+	return UpdateMachine(id, machine)
+}
+
+// UpdateMachineFirewall replaces the firewall configuration of the
+// machine identified by id and asks its cloud provider to apply it. If
+// the provider has no firewall support (or isn't registered), the local
+// row is still updated but the error is returned so the caller knows the
+// cloud side was not actually changed.
+func UpdateMachineFirewall(id string, enabled bool, rules []FirewallRule) (bool, error) {
+	machine, err := GetMachine(id)
+	if err != nil {
+		return false, err
+	}
+	if machine == nil {
+		return false, fmt.Errorf("the machine: %s does not exist", id)
+	}
+
+	machine.FirewallEnabled = enabled
+	machine.FirewallRules = rules
+
+	affected, err := UpdateMachine(id, machine)
+	if err != nil {
+		return affected, err
+	}
+
+	provider, err := GetCloudProvider(machine.ProviderType)
+	if err != nil {
+		return affected, err
+	}
+
+	if err = provider.ApplyFirewall(machine); err != nil {
+		return affected, err
+	}
+
+	return affected, nil
+}